@@ -0,0 +1,375 @@
+// Command shccn はシェルスクリプトの行数・コメント数・サイクロマティック
+// 複雑度(CCN)を計測するツール。pre-commit フックや CI のチェックとして
+// 使えるよう、複数の出力フォーマットとしきい値による終了コード制御を
+// 提供する。
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ko1nksm/infra-tools/pkg/shccn"
+	"github.com/ko1nksm/infra-tools/pkg/shccn/html"
+	"github.com/ko1nksm/infra-tools/pkg/shccn/mdextract"
+	"github.com/ko1nksm/infra-tools/pkg/shccn/profile"
+)
+
+func main() {
+	code, err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "shccn:", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// excludeFlags は -exclude を複数回指定できるようにするための flag.Value 実装。
+type excludeFlags []string
+
+func (e *excludeFlags) String() string { return strings.Join(*e, ",") }
+func (e *excludeFlags) Set(v string) error {
+	*e = append(*e, v)
+	return nil
+}
+
+func run(args []string) (int, error) {
+	fs := flag.NewFlagSet("shccn", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text, json, html, profile")
+	output := fs.String("o", "", "write the report to this file instead of stdout")
+	threshold := fs.Int("threshold", 0, "html format only: list functions whose CCN exceeds this value in the report sidebar")
+	ccnWarn := fs.Int("ccn-warn", 0, "print a warning to stderr for functions whose CCN exceeds this value (0 disables)")
+	ccnFail := fs.Int("ccn-fail", 0, "exit non-zero if any function's CCN exceeds this value (0 disables)")
+	minLines := fs.Int("min-lines", 0, "skip functions with fewer than this many code lines")
+	recursive := fs.Bool("r", false, "recursively walk directory arguments for *.sh/*.bash files and shebang scripts")
+	stdin := fs.Bool("stdin", false, "read a script from stdin instead of from file arguments")
+	funcProfile := fs.String("func", "", "read a CCN profile file and print a table sorted by CCN, ignoring all other flags")
+	ccnProfile := fs.String("ccnprofile", "", "write a CCN profile to this file (shorthand for -format=profile -o <file>)")
+	var excludes excludeFlags
+	fs.Var(&excludes, "exclude", "glob pattern to exclude from -r directory walks (can be given multiple times)")
+	if err := fs.Parse(args); err != nil {
+		return 2, err
+	}
+
+	if *funcProfile != "" {
+		return 0, runFuncTable(*funcProfile)
+	}
+
+	files, err := gatherFiles(fs.Args(), *stdin, *recursive, excludes)
+	if err != nil {
+		return 1, err
+	}
+	if len(files) == 0 {
+		return 1, fmt.Errorf("no script specified")
+	}
+
+	if *ccnProfile != "" {
+		f, err := os.Create(*ccnProfile)
+		if err != nil {
+			return 1, err
+		}
+		defer f.Close()
+		if err := writeProfileFormat(f, files); err != nil {
+			return 1, err
+		}
+		return checkThresholds(files, *minLines, *ccnWarn, *ccnFail)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return 1, err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "html":
+		err = html.RenderHTML(w, files, *threshold)
+	case "profile":
+		err = writeProfileFormat(w, files)
+	case "json":
+		err = writeJSONFormat(w, files, *minLines)
+	case "text":
+		err = writeTextFormat(w, files, *minLines)
+	default:
+		return 2, fmt.Errorf("unknown -format %q", *format)
+	}
+	if err != nil {
+		return 1, err
+	}
+
+	return checkThresholds(files, *minLines, *ccnWarn, *ccnFail)
+}
+
+// looksLikeShellFile は拡張子が .sh/.bash であるか、1行目が shebang で
+// シェルを指しているファイルかどうかを判定する(-r の再帰探索で使う)。
+func looksLikeShellFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".sh", ".bash":
+		return true
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "#!") && strings.Contains(line, "sh")
+}
+
+func isExcluded(path string, excludes []string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range excludes {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// gatherFiles は -stdin / -r / 通常のファイル引数のいずれかに応じて
+// FileContents の一覧を組み立てる。Markdown ファイルは拡張子 .md を見て
+// mdextract 経由でブロックごとに展開する。
+func gatherFiles(paths []string, stdin, recursive bool, excludes excludeFlags) ([]*shccn.FileContents, error) {
+	if stdin {
+		lines, err := readLines(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return []*shccn.FileContents{{Name: "<stdin>", Lines: lines}}, nil
+	}
+
+	var files []*shccn.FileContents
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			if !recursive {
+				return nil, fmt.Errorf("%s: is a directory (use -r to recurse)", path)
+			}
+			err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() || isExcluded(p, excludes) {
+					return nil
+				}
+				if strings.HasSuffix(p, ".md") {
+					blocks, err := mdextract.ExtractFile(p)
+					if err != nil {
+						return err
+					}
+					for _, b := range blocks {
+						files = append(files, b.FileContents)
+					}
+					return nil
+				}
+				if !looksLikeShellFile(p) {
+					return nil
+				}
+				fc, err := shccn.New(p)
+				if err != nil {
+					return err
+				}
+				files = append(files, fc)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if isExcluded(path, excludes) {
+			continue
+		}
+		if strings.HasSuffix(path, ".md") {
+			blocks, err := mdextract.ExtractFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			for _, b := range blocks {
+				files = append(files, b.FileContents)
+			}
+			continue
+		}
+		fc, err := shccn.New(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		files = append(files, fc)
+	}
+
+	return files, nil
+}
+
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// funcEntry は1関数ぶんの集計結果。text/json フォーマットで共通して使う。
+type funcEntry struct {
+	Name string `json:"name"`
+	Code int    `json:"code"`
+	CCN  int    `json:"ccn"`
+}
+
+// collectFuncEntries は profile.Collect (AST ベースの CCN 計算)を使って
+// ファイル中の関数を集計する。1行関数のように本体を行の再スライスで
+// 取り出せない関数も正しく CCN を持つ。
+func collectFuncEntries(fc *shccn.FileContents, minLines int) ([]funcEntry, error) {
+	profiles, err := profile.Collect(fc)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []funcEntry
+	for _, p := range profiles {
+		if p.Code < minLines {
+			continue
+		}
+		entries = append(entries, funcEntry{Name: p.Name, Code: p.Code, CCN: p.CCN})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CCN > entries[j].CCN })
+	return entries, nil
+}
+
+// writeTextFormat は BuildSummary/BuildFunction の各ビルダーを使い、従来の
+// 人間向けテキスト表を書き出す。
+func writeTextFormat(w io.Writer, files []*shccn.FileContents, minLines int) error {
+	fmt.Fprint(w, shccn.BuildSummaryHeader())
+	for _, fc := range files {
+		fmt.Fprint(w, shccn.BuildSummaryBody(fc.Name, fc.GetLines(), fc.GetCodeLines(),
+			fc.GetCommentLines(), fc.GetBlankLines(), fc.GetFunctionLines()))
+	}
+	fmt.Fprint(w, shccn.BuildSummaryFooter())
+
+	fmt.Fprint(w, shccn.BuildFunctionHeader())
+	for _, fc := range files {
+		entries, err := collectFuncEntries(fc, minLines)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fc.Name, err)
+		}
+		for _, e := range entries {
+			fmt.Fprint(w, shccn.BuildFunctionBody(fc.Name, e.Name, e.Code, e.CCN))
+		}
+	}
+	fmt.Fprint(w, shccn.BuildFunctionFooter())
+	return nil
+}
+
+// fileReport は -format=json の1ファイルぶんの出力。
+type fileReport struct {
+	Name      string      `json:"name"`
+	Lines     int         `json:"lines"`
+	Code      int         `json:"code"`
+	Comments  int         `json:"comments"`
+	Blanks    int         `json:"blanks"`
+	Functions []funcEntry `json:"functions"`
+}
+
+// writeJSONFormat はファイルごとに1つの JSON オブジェクトを改行区切りで
+// 書き出す(いわゆる NDJSON)。`jq` でそのままパイプ処理できるように、
+// 全体を配列でラップしない。
+func writeJSONFormat(w io.Writer, files []*shccn.FileContents, minLines int) error {
+	enc := json.NewEncoder(w)
+	for _, fc := range files {
+		entries, err := collectFuncEntries(fc, minLines)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fc.Name, err)
+		}
+		report := fileReport{
+			Name:      fc.Name,
+			Lines:     fc.GetLines(),
+			Code:      fc.GetCodeLines(),
+			Comments:  fc.GetCommentLines(),
+			Blanks:    fc.GetBlankLines(),
+			Functions: entries,
+		}
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeProfileFormat(w io.Writer, files []*shccn.FileContents) error {
+	var profiles []profile.FuncProfile
+	for _, fc := range files {
+		p, err := profile.Collect(fc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fc.Name, err)
+		}
+		profiles = append(profiles, p...)
+	}
+	return profile.WriteProfile(w, profiles)
+}
+
+// runFuncTable は -ccnprofile/-format=profile で作成済みのプロファイルを
+// 読み込み、CCN 降順の表を標準出力に書き出す。
+func runFuncTable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	profiles, err := profile.ParseProfile(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return profile.WriteFuncTable(os.Stdout, profiles)
+}
+
+// checkThresholds は -ccn-warn / -ccn-fail に基づいて stderr に警告を出し、
+// -ccn-fail を超える関数が1つでもあれば非ゼロの終了コードを返す。
+func checkThresholds(files []*shccn.FileContents, minLines, ccnWarn, ccnFail int) (int, error) {
+	if ccnWarn <= 0 && ccnFail <= 0 {
+		return 0, nil
+	}
+
+	failed := false
+	for _, fc := range files {
+		entries, err := collectFuncEntries(fc, minLines)
+		if err != nil {
+			return 1, fmt.Errorf("%s: %w", fc.Name, err)
+		}
+		for _, e := range entries {
+			switch {
+			case ccnFail > 0 && e.CCN > ccnFail:
+				fmt.Fprintf(os.Stderr, "shccn: %s@%s: CCN %d exceeds -ccn-fail=%d\n", e.Name, fc.Name, e.CCN, ccnFail)
+				failed = true
+			case ccnWarn > 0 && e.CCN > ccnWarn:
+				fmt.Fprintf(os.Stderr, "shccn: %s@%s: CCN %d exceeds -ccn-warn=%d\n", e.Name, fc.Name, e.CCN, ccnWarn)
+			}
+		}
+	}
+	if failed {
+		return 1, nil
+	}
+	return 0, nil
+}
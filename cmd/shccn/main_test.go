@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRunCCNFailSingleLineFunction は、1行関数 (`name() { ... }`) の CCN も
+// -ccn-fail のしきい値判定に正しく反映されることを確認する回帰テスト。
+// 修正前は GetFunctions がこの形の関数を丸ごと取りこぼし、しきい値を
+// 超えていても exit code 0 になっていた。
+func TestRunCCNFailSingleLineFunction(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "t.sh")
+	script := "risky() { a && b || c && echo x || echo y; }\n"
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	code, err := run([]string{"-ccn-fail", "2", path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 1 {
+		t.Errorf("run() code = %d, want 1 (risky's CCN exceeds -ccn-fail=2)", code)
+	}
+}
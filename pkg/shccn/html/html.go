@@ -0,0 +1,156 @@
+// Package html は shccn の解析結果を `go tool cover -html` 風の注釈付き
+// HTML レポートとして出力する。
+package html
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ko1nksm/infra-tools/pkg/shccn"
+	"github.com/ko1nksm/infra-tools/pkg/shccn/parser"
+)
+
+// function は1関数ぶんのメタ情報(行範囲・CCN)を表す。
+type function struct {
+	Name      string
+	StartLine int
+	EndLine   int
+	CCN       int
+	Code      int
+}
+
+// bucket は CCN の値に応じたヒートマップ上の区分を返す。
+// green <=5, yellow <=10, orange <=20, red >20。
+func bucket(ccn int) string {
+	switch {
+	case ccn <= 5:
+		return "green"
+	case ccn <= 10:
+		return "yellow"
+	case ccn <= 20:
+		return "orange"
+	default:
+		return "red"
+	}
+}
+
+// RenderHTML は files それぞれについて、ソースを1行ずつ関数の CCN 区分で
+// 色分けした HTML レポートを w に書き出す。threshold に正の値を渡すと、
+// サイドバーの関数一覧を CCN がその値を超えるものだけに絞り込む
+// (0 以下であれば全件表示)。
+func RenderHTML(w io.Writer, files []*shccn.FileContents, threshold int) error {
+	fmt.Fprint(w, htmlHeader)
+
+	for _, fc := range files {
+		funcs, err := collectFunctions(fc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fc.Name, err)
+		}
+		writeFileReport(w, fc, funcs, threshold)
+	}
+
+	fmt.Fprint(w, htmlFooter)
+	return nil
+}
+
+// collectFunctions は FileContents を解析し、行範囲と CCN を持つ関数一覧を
+// 作る。
+func collectFunctions(fc *shccn.FileContents) ([]function, error) {
+	file, err := parser.Parse(strings.Join(fc.Lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	var funcs []function
+	for _, fn := range file.Functions() {
+		start, end := fn.StartPos.Line, fn.EndPos.Line
+		body := shccn.FunctionBody(fc.Lines, start, end)
+		funcs = append(funcs, function{
+			Name:      fn.Name,
+			StartLine: start + fc.LineOffset,
+			EndLine:   end + fc.LineOffset,
+			CCN:       parser.CountCCN(fn.Body),
+			Code:      len(shccn.GetCodes(body)),
+		})
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].CCN > funcs[j].CCN })
+	return funcs, nil
+}
+
+func functionAt(funcs []function, lineNo int) (function, bool) {
+	for _, f := range funcs {
+		if f.StartLine == f.EndLine {
+			// 宣言行と閉じ括弧行が同一行の1行関数では、その行自体が
+			// 本体を兼ねる。
+			if lineNo == f.StartLine {
+				return f, true
+			}
+			continue
+		}
+		if lineNo > f.StartLine && lineNo < f.EndLine {
+			return f, true
+		}
+	}
+	return function{}, false
+}
+
+func writeFileReport(w io.Writer, fc *shccn.FileContents, funcs []function, threshold int) {
+	lines := fc.GetLines()
+	code := fc.GetCodeLines()
+	comments := fc.GetCommentLines()
+	blanks := fc.GetBlankLines()
+	functions := fc.GetFunctionLines()
+
+	fmt.Fprintf(w, "<section class=\"file\">\n")
+	fmt.Fprintf(w, "<h2>%s</h2>\n", html.EscapeString(fc.Name))
+	fmt.Fprintf(w, "<table class=\"summary\"><tr><th>Lines</th><th>Code</th><th>Comments</th><th>Blanks</th><th>Functions</th></tr>\n")
+	fmt.Fprintf(w, "<tr><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td></tr></table>\n",
+		lines, code, comments, blanks, functions)
+
+	fmt.Fprintf(w, "<div class=\"layout\">\n<ol class=\"source\">\n")
+	for i, line := range fc.Lines {
+		lineNo := i + 1 + fc.LineOffset
+		class := ""
+		if fn, ok := functionAt(funcs, lineNo); ok {
+			class = " class=\"ccn-" + bucket(fn.CCN) + "\""
+		}
+		fmt.Fprintf(w, "<li id=\"%s-L%d\"%s>%s</li>\n", html.EscapeString(fc.Name), lineNo, class, html.EscapeString(line))
+	}
+	fmt.Fprintf(w, "</ol>\n<aside class=\"functions\">\n<h3>Functions</h3>\n<ul>\n")
+	for _, fn := range funcs {
+		if threshold > 0 && fn.CCN <= threshold {
+			continue
+		}
+		fmt.Fprintf(w, "<li class=\"ccn-%s\"><a href=\"#%s-L%d\">%s</a> (code=%d, ccn=%d)</li>\n",
+			bucket(fn.CCN), html.EscapeString(fc.Name), fn.StartLine, html.EscapeString(fn.Name), fn.Code, fn.CCN)
+	}
+	fmt.Fprintf(w, "</ul>\n</aside>\n</div>\n</section>\n")
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>shccn report</title>
+<style>
+body { font-family: monospace; margin: 0; padding: 1em; }
+.layout { display: flex; gap: 1em; }
+.source { flex: 1; counter-reset: none; list-style: decimal; white-space: pre; overflow-x: auto; }
+.functions { width: 320px; }
+.ccn-green { background-color: #d6f5d6; }
+.ccn-yellow { background-color: #fdf3c0; }
+.ccn-orange { background-color: #fcdca0; }
+.ccn-red { background-color: #f7b8b8; }
+table.summary { border-collapse: collapse; margin-bottom: 1em; }
+table.summary td, table.summary th { border: 1px solid #ccc; padding: 0.2em 0.5em; }
+</style>
+</head>
+<body>
+`
+
+const htmlFooter = `</body>
+</html>
+`
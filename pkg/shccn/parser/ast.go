@@ -0,0 +1,124 @@
+package parser
+
+// Node はこのパッケージが組み立てる AST ノードに共通するインタフェース。
+type Node interface {
+	Pos() Position
+}
+
+// File はスクリプト全体を表すルートノード。トップレベルの文(関数定義を
+// 含む)を順番に保持する。
+type File struct {
+	Decls    []Node
+	StartPos Position
+	EndPos   Position
+}
+
+// Pos は File の開始位置を返す。
+func (f *File) Pos() Position { return f.StartPos }
+
+// FuncDecl は `name() { ... }` / `function name { ... }` 形式の関数定義。
+type FuncDecl struct {
+	Name     string
+	Body     *BlockStmt
+	StartPos Position
+	EndPos   Position
+}
+
+// Pos は関数定義の開始位置を返す。
+func (f *FuncDecl) Pos() Position { return f.StartPos }
+
+// BlockStmt は文の並びを表す。
+type BlockStmt struct {
+	Stmts    []Node
+	StartPos Position
+}
+
+// Pos は BlockStmt の開始位置を返す。
+func (b *BlockStmt) Pos() Position { return b.StartPos }
+
+// IfStmt は if/elif/else を表す。Elifs は elif 節を IfStmt として連ねる。
+type IfStmt struct {
+	Cond     *Pipeline
+	Then     *BlockStmt
+	Elifs    []*IfStmt
+	Else     *BlockStmt
+	StartPos Position
+}
+
+// Pos は IfStmt の開始位置を返す。
+func (s *IfStmt) Pos() Position { return s.StartPos }
+
+// WhileStmt は while ... do ... done を表す。
+type WhileStmt struct {
+	Cond     *Pipeline
+	Body     *BlockStmt
+	StartPos Position
+}
+
+// Pos は WhileStmt の開始位置を返す。
+func (s *WhileStmt) Pos() Position { return s.StartPos }
+
+// UntilStmt は until ... do ... done を表す。
+type UntilStmt struct {
+	Cond     *Pipeline
+	Body     *BlockStmt
+	StartPos Position
+}
+
+// Pos は UntilStmt の開始位置を返す。
+func (s *UntilStmt) Pos() Position { return s.StartPos }
+
+// ForStmt は for ... do ... done を表す(C 形式の for (( ; ; )) も含む)。
+type ForStmt struct {
+	Var      string
+	Words    []string
+	Body     *BlockStmt
+	StartPos Position
+}
+
+// Pos は ForStmt の開始位置を返す。
+func (s *ForStmt) Pos() Position { return s.StartPos }
+
+// CaseStmt は case ... in ... esac を表す。
+type CaseStmt struct {
+	Word     string
+	Clauses  []*CaseClause
+	StartPos Position
+}
+
+// Pos は CaseStmt の開始位置を返す。
+func (s *CaseStmt) Pos() Position { return s.StartPos }
+
+// CaseClause は case 文の1つの `pattern) ... ;;` 節を表す。
+type CaseClause struct {
+	Patterns []string
+	Body     *BlockStmt
+	StartPos Position
+}
+
+// Pos は CaseClause の開始位置を返す。
+func (c *CaseClause) Pos() Position { return c.StartPos }
+
+// Command は単語列からなる単純なコマンドを表す。HasTernary は、このコマンドが
+// `[[ ... ? ... ]]` のように `[[ ]]` 内で `?` (三項演算子的な利用)を含む
+// 場合に立つ。Words の中身を走査して判定すると `echo "?"` のような本物の
+// 単語と区別がつかないため、専用のフラグとして独立に持たせている。
+type Command struct {
+	Words      []string
+	HasTernary bool
+	StartPos   Position
+}
+
+// Pos は Command の開始位置を返す。
+func (c *Command) Pos() Position { return c.StartPos }
+
+// Pipeline は `cmd1 | cmd2 && cmd3 ...` を表す。Ops はコマンド間に現れた
+// 論理演算子(&&, ||)だけを出現順に保持し、`|` の境界は記録しない。
+type Pipeline struct {
+	Commands []*Command
+	Ops      []TokenKind // ANDAND か OROR。`|` を含むと len(Ops) < len(Commands)-1
+	StartPos Position
+}
+
+// Pos は Pipeline の開始位置を返す。
+func (p *Pipeline) Pos() Position { return p.StartPos }
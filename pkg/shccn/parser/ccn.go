@@ -0,0 +1,100 @@
+package parser
+
+// CountCCN は AST を辿り、サイクロマティック複雑度(decision point の数+1)
+// を計算する。数え上げる対象は if/elif/while/until/for の各ブロック、
+// case の各パターン節、`&&`/`||`、`[[ ... ]]` 内の三項演算子的な `?` 利用。
+func CountCCN(node Node) int {
+	return 1 + countDecisionPoints(node)
+}
+
+func countDecisionPoints(node Node) int {
+	switch n := node.(type) {
+	case *File:
+		count := 0
+		for _, d := range n.Decls {
+			count += countDecisionPoints(d)
+		}
+		return count
+
+	case *FuncDecl:
+		return countDecisionPoints(n.Body)
+
+	case *BlockStmt:
+		count := 0
+		for _, s := range n.Stmts {
+			count += countDecisionPoints(s)
+		}
+		return count
+
+	case *IfStmt:
+		count := 1 // if
+		count += countPipelineOps(n.Cond)
+		count += countDecisionPoints(n.Then)
+		for _, elif := range n.Elifs {
+			count++ // elif
+			count += countPipelineOps(elif.Cond)
+			count += countDecisionPoints(elif.Then)
+		}
+		if n.Else != nil {
+			count += countDecisionPoints(n.Else)
+		}
+		return count
+
+	case *WhileStmt:
+		count := 1
+		count += countPipelineOps(n.Cond)
+		count += countDecisionPoints(n.Body)
+		return count
+
+	case *UntilStmt:
+		count := 1
+		count += countPipelineOps(n.Cond)
+		count += countDecisionPoints(n.Body)
+		return count
+
+	case *ForStmt:
+		count := 1
+		count += countDecisionPoints(n.Body)
+		return count
+
+	case *CaseStmt:
+		count := 0
+		for _, clause := range n.Clauses {
+			count++ // 各パターン節が decision point
+			count += countDecisionPoints(clause.Body)
+		}
+		return count
+
+	case *Pipeline:
+		return countPipelineOps(n)
+
+	default:
+		return 0
+	}
+}
+
+// countPipelineOps はパイプライン中の `&&`/`||` と `[[ ]]` 内の三項演算子
+// 利用をカウントする。nil 安全。
+func countPipelineOps(p *Pipeline) int {
+	if p == nil {
+		return 0
+	}
+	count := len(p.Ops)
+	for _, cmd := range p.Commands {
+		if cmd.HasTernary {
+			count++
+		}
+	}
+	return count
+}
+
+// Functions は File 中のトップレベル関数定義を順番に返す。
+func (f *File) Functions() []*FuncDecl {
+	var result []*FuncDecl
+	for _, d := range f.Decls {
+		if fn, ok := d.(*FuncDecl); ok {
+			result = append(result, fn)
+		}
+	}
+	return result
+}
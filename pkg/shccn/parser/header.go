@@ -0,0 +1,41 @@
+package parser
+
+// DetectFuncHeader は1行が関数宣言ヘッダ(`name() {` または
+// `function name {`)かどうかを判定し、真であれば関数名を返す。GetFunctions
+// のように行単位でストリーム処理する既存ロジックとの互換のために用意した
+// 軽量なヘルパーで、本体の `}` は含まれていなくてよい。クオートや
+// コマンド置換の中身は Lexer が正しく読み飛ばす。
+func DetectFuncHeader(line string) (name string, ok bool) {
+	tokens, err := NewLexer(line).Tokenize()
+	if err != nil {
+		return "", false
+	}
+	p := &parser{tokens: tokens}
+
+	explicitFunction := false
+	if p.atKeyword("function") {
+		explicitFunction = true
+		p.advance()
+	}
+
+	if p.cur().Kind != WORD {
+		return "", false
+	}
+	fname := p.advance().Value
+
+	if p.cur().Kind == LPAREN {
+		p.advance()
+		if p.cur().Kind != RPAREN {
+			return "", false
+		}
+		p.advance()
+	} else if !explicitFunction {
+		return "", false
+	}
+
+	p.skipSeparators()
+	if p.cur().Kind != LBRACE {
+		return "", false
+	}
+	return fname, true
+}
@@ -0,0 +1,392 @@
+package parser
+
+import (
+	"strings"
+)
+
+// Lexer はシェルスクリプトのソースを1文字ずつ走査してトークン列を作る。
+type Lexer struct {
+	src  []rune
+	pos  int
+	line int
+	col  int
+}
+
+// NewLexer は src を字句解析する Lexer を初期化する。
+func NewLexer(src string) *Lexer {
+	return &Lexer{src: []rune(src), line: 1, col: 1}
+}
+
+func (l *Lexer) peek() rune {
+	if l.pos >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos]
+}
+
+func (l *Lexer) peekAt(offset int) rune {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+func (l *Lexer) advance() rune {
+	r := l.src[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return r
+}
+
+func (l *Lexer) atEnd() bool {
+	return l.pos >= len(l.src)
+}
+
+// Tokenize はソース全体をトークン列に変換する。heredoc の開始(<<, <<-)を
+// 検出した場合は、続く改行の直後から終端ワードまでを1つの HEREDOC トークンに
+// まとめて読み飛ばす。
+func (l *Lexer) Tokenize() ([]Token, error) {
+	var tokens []Token
+	var pendingHeredocs []heredocRequest
+
+	for !l.atEnd() {
+		r := l.peek()
+
+		switch {
+		case r == '\n':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: NEWLINE, Value: "\n", Pos: pos})
+			if len(pendingHeredocs) > 0 {
+				for _, req := range pendingHeredocs {
+					body, err := l.readHeredoc(req)
+					if err != nil {
+						return nil, err
+					}
+					tokens = append(tokens, Token{Kind: HEREDOC, Value: body, Pos: req.pos})
+				}
+				pendingHeredocs = nil
+			}
+			continue
+
+		case r == ' ' || r == '\t' || r == '\r':
+			l.advance()
+			continue
+
+		case r == '#':
+			l.skipComment()
+			continue
+
+		case r == '\'':
+			tok, err := l.readSingleQuoted()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+
+		case r == '"':
+			tok, err := l.readDoubleQuoted()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+
+		case r == '$' && l.peekAt(1) == '(':
+			tok := l.readCommandSubstitution()
+			tokens = append(tokens, tok)
+
+		case r == '{':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: LBRACE, Value: "{", Pos: pos})
+
+		case r == '}':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: RBRACE, Value: "}", Pos: pos})
+
+		case r == '(' && l.peekAt(1) == '(':
+			pos := l.currentPos()
+			l.advance()
+			l.advance()
+			tokens = append(tokens, Token{Kind: DLPAREN, Value: "((", Pos: pos})
+
+		case r == ')' && l.peekAt(1) == ')':
+			pos := l.currentPos()
+			l.advance()
+			l.advance()
+			tokens = append(tokens, Token{Kind: DRPAREN, Value: "))", Pos: pos})
+
+		case r == '(':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: LPAREN, Value: "(", Pos: pos})
+
+		case r == ')':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: RPAREN, Value: ")", Pos: pos})
+
+		case r == '[' && l.peekAt(1) == '[':
+			pos := l.currentPos()
+			l.advance()
+			l.advance()
+			tokens = append(tokens, Token{Kind: DLBRACKET, Value: "[[", Pos: pos})
+
+		case r == ']' && l.peekAt(1) == ']':
+			pos := l.currentPos()
+			l.advance()
+			l.advance()
+			tokens = append(tokens, Token{Kind: DRBRACKET, Value: "]]", Pos: pos})
+
+		case r == ';' && l.peekAt(1) == ';':
+			pos := l.currentPos()
+			l.advance()
+			l.advance()
+			tokens = append(tokens, Token{Kind: DSEMI, Value: ";;", Pos: pos})
+
+		case r == ';':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: SEMI, Value: ";", Pos: pos})
+
+		case r == '|' && l.peekAt(1) == '|':
+			pos := l.currentPos()
+			l.advance()
+			l.advance()
+			tokens = append(tokens, Token{Kind: OROR, Value: "||", Pos: pos})
+
+		case r == '|':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: PIPE, Value: "|", Pos: pos})
+
+		case r == '&' && l.peekAt(1) == '&':
+			pos := l.currentPos()
+			l.advance()
+			l.advance()
+			tokens = append(tokens, Token{Kind: ANDAND, Value: "&&", Pos: pos})
+
+		case r == '&':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: AMP, Value: "&", Pos: pos})
+
+		case r == '?':
+			pos := l.currentPos()
+			l.advance()
+			tokens = append(tokens, Token{Kind: QUESTION, Value: "?", Pos: pos})
+
+		case r == '<' && (l.peekAt(1) == '<'):
+			req := l.readHeredocOperator()
+			pendingHeredocs = append(pendingHeredocs, req)
+
+		default:
+			tok := l.readWord()
+			if tok.Value == "" {
+				// 予期しない記号。1文字読み飛ばして継続する。
+				l.advance()
+				continue
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+
+	tokens = append(tokens, Token{Kind: EOF, Pos: l.currentPos()})
+	return tokens, nil
+}
+
+func (l *Lexer) currentPos() Position {
+	return Position{Line: l.line, Col: l.col}
+}
+
+func (l *Lexer) skipComment() {
+	for !l.atEnd() && l.peek() != '\n' {
+		l.advance()
+	}
+}
+
+func (l *Lexer) readSingleQuoted() (Token, error) {
+	pos := l.currentPos()
+	var b strings.Builder
+	l.advance() // opening '
+	for {
+		if l.atEnd() {
+			return Token{}, &UnterminatedError{What: "single-quoted string", Pos: pos}
+		}
+		r := l.advance()
+		if r == '\'' {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return Token{Kind: STRING, Value: b.String(), Pos: pos}, nil
+}
+
+func (l *Lexer) readDoubleQuoted() (Token, error) {
+	pos := l.currentPos()
+	var b strings.Builder
+	l.advance() // opening "
+	for {
+		if l.atEnd() {
+			return Token{}, &UnterminatedError{What: "double-quoted string", Pos: pos}
+		}
+		r := l.advance()
+		if r == '\\' && !l.atEnd() {
+			b.WriteRune(r)
+			b.WriteRune(l.advance())
+			continue
+		}
+		if r == '"' {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return Token{Kind: STRING, Value: b.String(), Pos: pos}, nil
+}
+
+// readCommandSubstitution は $( ... ) をネスト込みで読み飛ばし、1つの WORD
+// トークンとして扱う。中の if/while/&& などはキーワードとして扱わない。
+func (l *Lexer) readCommandSubstitution() Token {
+	pos := l.currentPos()
+	var b strings.Builder
+	b.WriteRune(l.advance()) // $
+	b.WriteRune(l.advance()) // (
+	depth := 1
+	for !l.atEnd() && depth > 0 {
+		r := l.advance()
+		b.WriteRune(r)
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	return Token{Kind: WORD, Value: b.String(), Pos: pos}
+}
+
+func isWordBreak(r rune) bool {
+	switch r {
+	case 0, ' ', '\t', '\r', '\n', ';', '|', '&', '(', ')', '{', '}', '\'', '"', '#', '?':
+		return true
+	}
+	return false
+}
+
+func (l *Lexer) readWord() Token {
+	pos := l.currentPos()
+	var b strings.Builder
+	for !l.atEnd() && !isWordBreak(l.peek()) {
+		r := l.peek()
+		if r == '$' && l.peekAt(1) == '(' {
+			sub := l.readCommandSubstitution()
+			b.WriteString(sub.Value)
+			continue
+		}
+		if r == '$' && l.peekAt(1) == '?' {
+			// 終了ステータスの特殊パラメータ $? は単語の一部として読む。
+			b.WriteRune(l.advance())
+			b.WriteRune(l.advance())
+			continue
+		}
+		if r == '<' && l.peekAt(1) == '<' {
+			break
+		}
+		if r == '[' && l.peekAt(1) == '[' {
+			break
+		}
+		if r == ']' && l.peekAt(1) == ']' {
+			break
+		}
+		b.WriteRune(l.advance())
+	}
+	word := b.String()
+	if word == "" {
+		return Token{}
+	}
+	return Token{Kind: WORD, Value: word, Pos: pos}
+}
+
+type heredocRequest struct {
+	delim  string
+	strip  bool // <<- はタブの前置を読み飛ばす
+	quoted bool // 'EOF' / "EOF" 形式は展開されない(tokenizer 上は区別不要)
+	pos    Position
+}
+
+// readHeredocOperator は << または <<- とそれに続く終端ワードを読み取る。
+// 本文の読み取りは次の改行以降に行う(readHeredoc)。
+func (l *Lexer) readHeredocOperator() heredocRequest {
+	pos := l.currentPos()
+	l.advance() // <
+	l.advance() // <
+	strip := false
+	if l.peek() == '-' {
+		strip = true
+		l.advance()
+	}
+	for !l.atEnd() && (l.peek() == ' ' || l.peek() == '\t') {
+		l.advance()
+	}
+	var b strings.Builder
+	quoted := false
+	if l.peek() == '\'' || l.peek() == '"' {
+		quote := l.advance()
+		quoted = true
+		for !l.atEnd() && l.peek() != quote {
+			b.WriteRune(l.advance())
+		}
+		if !l.atEnd() {
+			l.advance()
+		}
+	} else {
+		for !l.atEnd() && !isWordBreak(l.peek()) {
+			b.WriteRune(l.advance())
+		}
+	}
+	return heredocRequest{delim: b.String(), strip: strip, quoted: quoted, pos: pos}
+}
+
+// readHeredoc は直前の改行の次の行から終端ワードのみの行までを本文として
+// 読み取る。
+func (l *Lexer) readHeredoc(req heredocRequest) (string, error) {
+	var b strings.Builder
+	for {
+		lineStart := l.pos
+		for !l.atEnd() && l.peek() != '\n' {
+			l.advance()
+		}
+		line := string(l.src[lineStart:l.pos])
+		if !l.atEnd() {
+			l.advance() // consume '\n'
+		}
+		check := line
+		if req.strip {
+			check = strings.TrimLeft(check, "\t")
+		}
+		if check == req.delim {
+			return b.String(), nil
+		}
+		b.WriteString(line)
+		b.WriteRune('\n')
+		if l.atEnd() {
+			return b.String(), &UnterminatedError{What: "heredoc <<" + req.delim, Pos: req.pos}
+		}
+	}
+}
+
+// UnterminatedError は閉じられていない引用符や heredoc を検出したときに返す。
+type UnterminatedError struct {
+	What string
+	Pos  Position
+}
+
+func (e *UnterminatedError) Error() string {
+	return "unterminated " + e.What + " starting at " + e.Pos.String()
+}
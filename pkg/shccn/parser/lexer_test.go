@@ -0,0 +1,66 @@
+package parser
+
+import "testing"
+
+func tokenKinds(tokens []Token) []TokenKind {
+	kinds := make([]TokenKind, len(tokens))
+	for i, t := range tokens {
+		kinds[i] = t.Kind
+	}
+	return kinds
+}
+
+// TestTokenizeAlwaysEmitsWord は、if/then/do/done などの予約語の綴りが
+// 専用の TokenKind を持たず常に WORD として字句解析されることを確認する。
+// キーワードかどうかの判定はパーサの責務であり、Lexer はそれを一切行わない。
+func TestTokenizeAlwaysEmitsWord(t *testing.T) {
+	for _, word := range []string{"if", "then", "do", "done", "for", "in", "case", "esac", "function"} {
+		tokens, err := NewLexer(word).Tokenize()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", word, err)
+		}
+		if len(tokens) != 2 || tokens[0].Kind != WORD || tokens[0].Value != word {
+			t.Fatalf("%q: want single WORD token, got %#v", word, tokens)
+		}
+	}
+}
+
+func TestTokenizeCommandSubstitutionIsOneWord(t *testing.T) {
+	tokens, err := NewLexer(`x=$(echo $(echo hi))`).Tokenize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].Kind != WORD {
+		t.Fatalf("want a single WORD token, got %#v", tokens)
+	}
+	if tokens[0].Value != `x=$(echo $(echo hi))` {
+		t.Fatalf("command substitution not kept verbatim: %q", tokens[0].Value)
+	}
+}
+
+func TestTokenizeHeredoc(t *testing.T) {
+	src := "cat <<EOF\nif done\nEOF\n"
+	tokens, err := NewLexer(src).Tokenize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var heredocs []string
+	for _, tok := range tokens {
+		if tok.Kind == HEREDOC {
+			heredocs = append(heredocs, tok.Value)
+		}
+	}
+	if len(heredocs) != 1 || heredocs[0] != "if done\n" {
+		t.Fatalf("unexpected heredoc body: %#v", heredocs)
+	}
+}
+
+func TestTokenizeDoubleQuotedEscape(t *testing.T) {
+	tokens, err := NewLexer(`"a\"b"`).Tokenize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].Kind != STRING || tokens[0].Value != `a\"b` {
+		t.Fatalf("unexpected tokens: %#v", tokens)
+	}
+}
@@ -0,0 +1,512 @@
+package parser
+
+import "fmt"
+
+// parser はトークン列を先読みしながら AST を組み立てる再帰下降パーサ。
+type parser struct {
+	tokens []Token
+	pos    int
+}
+
+// Parse はソース文字列をトークン化したうえで File を構築する。
+func Parse(src string) (*File, error) {
+	tokens, err := NewLexer(src).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	return ParseTokens(tokens)
+}
+
+// ParseTokens はすでに字句解析済みのトークン列から File を構築する。
+func ParseTokens(tokens []Token) (*File, error) {
+	p := &parser{tokens: tokens}
+	start := p.cur().Pos
+	decls, err := p.parseStmtList(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Decls: decls, StartPos: start, EndPos: p.cur().Pos}, nil
+}
+
+// ParseBlock は関数本体など、単独のコード片を BlockStmt として解析する。
+// CalculateCCN のように「関数1つぶんの行」を受け取って処理する用途向け。
+func ParseBlock(src string) (*BlockStmt, error) {
+	tokens, err := NewLexer(src).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	start := p.cur().Pos
+	stmts, err := p.parseStmtList(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockStmt{Stmts: stmts, StartPos: start}, nil
+}
+
+func (p *parser) cur() Token {
+	if p.pos >= len(p.tokens) {
+		return Token{Kind: EOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() Token {
+	t := p.cur()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) skipSeparators() {
+	for {
+		switch p.cur().Kind {
+		case NEWLINE, SEMI, AMP:
+			p.advance()
+			continue
+		}
+		return
+	}
+}
+
+// parseStmtList は endKinds のトークン種別、または endWords のキーワードの
+// いずれかに出会うまで文を読み続ける。両者とも空でよい(その場合は EOF まで
+// 読む)。
+func (p *parser) parseStmtList(endKinds []TokenKind, endWords ...string) ([]Node, error) {
+	var stmts []Node
+	for {
+		p.skipSeparators()
+		if p.atAny(endKinds...) || p.atAnyKeyword(endWords...) {
+			return stmts, nil
+		}
+		if p.cur().Kind == EOF {
+			return stmts, nil
+		}
+		stmt, err := p.parseStmt()
+		if err != nil {
+			return nil, err
+		}
+		if stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+}
+
+func (p *parser) atAny(kinds ...TokenKind) bool {
+	cur := p.cur().Kind
+	for _, k := range kinds {
+		if cur == k {
+			return true
+		}
+	}
+	return false
+}
+
+// atKeyword は、カレントトークンが WORD でありその綴りが word と一致する
+// かどうかを返す。if/then/do/done などのシェル予約語は専用の TokenKind を
+// 持たず常に WORD として字句解析されるため、キーワードかどうかの判定は
+// 文法上その位置にキーワードが来るべき箇所でこのメソッドを呼んで行う。
+// `echo done` のように引数として現れた同じ綴りは、この判定を呼ばない限り
+// ただの WORD のまま扱われる。
+func (p *parser) atKeyword(word string) bool {
+	cur := p.cur()
+	return cur.Kind == WORD && cur.Value == word
+}
+
+// advanceKeyword は atKeyword(word) が真であれば読み進めて true を返す。
+func (p *parser) advanceKeyword(word string) bool {
+	if p.atKeyword(word) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+// expectKeyword は expect のキーワード版。一致しなくても解析を止めない。
+func (p *parser) expectKeyword(word string) {
+	p.advanceKeyword(word)
+}
+
+// atAnyKeyword は words のいずれかが atKeyword で真になるかを返す。
+func (p *parser) atAnyKeyword(words ...string) bool {
+	for _, w := range words {
+		if p.atKeyword(w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseStmt() (Node, error) {
+	switch p.cur().Kind {
+	case WORD:
+		switch p.cur().Value {
+		case "if":
+			return p.parseIf()
+		case "while":
+			return p.parseWhile()
+		case "until":
+			return p.parseUntil()
+		case "for":
+			return p.parseFor()
+		case "case":
+			return p.parseCase()
+		}
+		if fn, ok, err := p.tryParseFuncDecl(); ok {
+			return fn, err
+		}
+		return p.parsePipeline()
+	case LBRACE:
+		return p.parseBraceGroup()
+	default:
+		// 認識できないトークンは1つ読み飛ばして前進する。
+		p.advance()
+		return nil, nil
+	}
+}
+
+// tryParseFuncDecl は `name() {`、`name() { ... }` が1行に収まっている形、
+// `function name { ... }` のいずれかを試みる。関数定義でなければ
+// ok=false を返し、トークン位置は変更しない。
+func (p *parser) tryParseFuncDecl() (*FuncDecl, bool, error) {
+	save := p.pos
+	start := p.cur().Pos
+
+	explicitFunction := false
+	if p.atKeyword("function") {
+		explicitFunction = true
+		p.advance()
+	}
+
+	if p.cur().Kind != WORD {
+		p.pos = save
+		return nil, false, nil
+	}
+	name := p.advance().Value
+
+	if explicitFunction {
+		// `function name { ... }` は丸括弧なしもありうる。
+		if p.cur().Kind == LPAREN {
+			p.advance()
+			if p.cur().Kind != RPAREN {
+				p.pos = save
+				return nil, false, nil
+			}
+			p.advance()
+		}
+	} else {
+		if p.cur().Kind != LPAREN {
+			p.pos = save
+			return nil, false, nil
+		}
+		p.advance()
+		if p.cur().Kind != RPAREN {
+			p.pos = save
+			return nil, false, nil
+		}
+		p.advance()
+	}
+
+	p.skipSeparators()
+	if p.cur().Kind != LBRACE {
+		p.pos = save
+		return nil, false, nil
+	}
+	p.advance()
+
+	body, err := p.parseStmtList([]TokenKind{RBRACE})
+	if err != nil {
+		return nil, true, err
+	}
+	end := p.cur().Pos
+	if p.cur().Kind != RBRACE {
+		return nil, true, fmt.Errorf("function %q: %w", name, &UnterminatedError{What: "function body", Pos: start})
+	}
+	p.advance()
+
+	return &FuncDecl{
+		Name:     name,
+		Body:     &BlockStmt{Stmts: body, StartPos: start},
+		StartPos: start,
+		EndPos:   end,
+	}, true, nil
+}
+
+func (p *parser) parseBraceGroup() (Node, error) {
+	start := p.cur().Pos
+	p.advance() // {
+	stmts, err := p.parseStmtList([]TokenKind{RBRACE})
+	if err != nil {
+		return nil, err
+	}
+	if p.cur().Kind == RBRACE {
+		p.advance()
+	}
+	return &BlockStmt{Stmts: stmts, StartPos: start}, nil
+}
+
+func (p *parser) parseIf() (Node, error) {
+	start := p.cur().Pos
+	p.advance() // if
+	cond, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSeparators()
+	p.expectKeyword("then")
+	thenStmts, err := p.parseStmtList(nil, "elif", "else", "fi")
+	if err != nil {
+		return nil, err
+	}
+	stmt := &IfStmt{Cond: cond.(*Pipeline), Then: &BlockStmt{Stmts: thenStmts, StartPos: start}, StartPos: start}
+
+	for p.atKeyword("elif") {
+		elifStart := p.cur().Pos
+		p.advance()
+		elifCond, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSeparators()
+		p.expectKeyword("then")
+		elifStmts, err := p.parseStmtList(nil, "elif", "else", "fi")
+		if err != nil {
+			return nil, err
+		}
+		stmt.Elifs = append(stmt.Elifs, &IfStmt{
+			Cond:     elifCond.(*Pipeline),
+			Then:     &BlockStmt{Stmts: elifStmts, StartPos: elifStart},
+			StartPos: elifStart,
+		})
+	}
+
+	if p.atKeyword("else") {
+		p.advance()
+		elseStmts, err := p.parseStmtList(nil, "fi")
+		if err != nil {
+			return nil, err
+		}
+		stmt.Else = &BlockStmt{Stmts: elseStmts, StartPos: start}
+	}
+
+	p.expectKeyword("fi")
+	return stmt, nil
+}
+
+func (p *parser) parseWhile() (Node, error) {
+	start := p.cur().Pos
+	p.advance() // while
+	cond, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.parseDoBlock()
+	if err != nil {
+		return nil, err
+	}
+	return &WhileStmt{Cond: cond.(*Pipeline), Body: body, StartPos: start}, nil
+}
+
+func (p *parser) parseUntil() (Node, error) {
+	start := p.cur().Pos
+	p.advance() // until
+	cond, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.parseDoBlock()
+	if err != nil {
+		return nil, err
+	}
+	return &UntilStmt{Cond: cond.(*Pipeline), Body: body, StartPos: start}, nil
+}
+
+func (p *parser) parseDoBlock() (*BlockStmt, error) {
+	p.skipSeparators()
+	p.expectKeyword("do")
+	stmts, err := p.parseStmtList(nil, "done")
+	if err != nil {
+		return nil, err
+	}
+	start := p.cur().Pos
+	p.expectKeyword("done")
+	return &BlockStmt{Stmts: stmts, StartPos: start}, nil
+}
+
+func (p *parser) parseFor() (Node, error) {
+	start := p.cur().Pos
+	p.advance() // for
+
+	if p.cur().Kind == DLPAREN {
+		// C 形式の for (( init; cond; post )) はここでは条件式を丸ごと
+		// 単語列として読み飛ばす(CCN のカウント対象は do ブロックのみ)。
+		depth := 0
+		for {
+			k := p.cur().Kind
+			if k == EOF {
+				break
+			}
+			if k == DLPAREN {
+				depth++
+			}
+			if k == DRPAREN {
+				depth--
+				p.advance()
+				if depth == 0 {
+					break
+				}
+				continue
+			}
+			p.advance()
+		}
+		body, err := p.parseDoBlock()
+		if err != nil {
+			return nil, err
+		}
+		return &ForStmt{Body: body, StartPos: start}, nil
+	}
+
+	var varName string
+	if p.cur().Kind == WORD {
+		varName = p.advance().Value
+	}
+	var words []string
+	if p.atKeyword("in") {
+		p.advance()
+		for p.cur().Kind == WORD || p.cur().Kind == STRING {
+			words = append(words, p.advance().Value)
+		}
+	}
+	body, err := p.parseDoBlock()
+	if err != nil {
+		return nil, err
+	}
+	return &ForStmt{Var: varName, Words: words, Body: body, StartPos: start}, nil
+}
+
+func (p *parser) parseCase() (Node, error) {
+	start := p.cur().Pos
+	p.advance() // case
+	var word string
+	if p.cur().Kind == WORD || p.cur().Kind == STRING {
+		word = p.advance().Value
+	}
+	p.skipSeparators()
+	p.expectKeyword("in")
+	p.skipSeparators()
+
+	stmt := &CaseStmt{Word: word, StartPos: start}
+	for !p.atKeyword("esac") && p.cur().Kind != EOF {
+		clauseStart := p.cur().Pos
+		if p.cur().Kind == LPAREN {
+			p.advance()
+		}
+		var patterns []string
+		for {
+			if p.cur().Kind == WORD || p.cur().Kind == STRING {
+				patterns = append(patterns, p.advance().Value)
+			}
+			if p.cur().Kind == PIPE {
+				p.advance()
+				continue
+			}
+			break
+		}
+		if p.cur().Kind == RPAREN {
+			p.advance()
+		}
+		body, err := p.parseStmtList([]TokenKind{DSEMI}, "esac")
+		if err != nil {
+			return nil, err
+		}
+		if p.cur().Kind == DSEMI {
+			p.advance()
+		}
+		p.skipSeparators()
+		stmt.Clauses = append(stmt.Clauses, &CaseClause{
+			Patterns: patterns,
+			Body:     &BlockStmt{Stmts: body, StartPos: clauseStart},
+			StartPos: clauseStart,
+		})
+	}
+	p.expectKeyword("esac")
+	return stmt, nil
+}
+
+// parsePipeline は `cmd1 | cmd2 && cmd3 || cmd4` のようなパイプライン・
+// 条件リストを1つ読み取る。[[ ... ]] の内側に現れる `?` は三項演算子的な
+// 利用とみなし、対応する Command の HasTernary に記録する。
+func (p *parser) parsePipeline() (Node, error) {
+	start := p.cur().Pos
+	pipeline := &Pipeline{StartPos: start}
+
+	cmd, err := p.parseCommand()
+	if err != nil {
+		return nil, err
+	}
+	pipeline.Commands = append(pipeline.Commands, cmd)
+
+	for {
+		switch p.cur().Kind {
+		case PIPE:
+			p.advance()
+			next, err := p.parseCommand()
+			if err != nil {
+				return nil, err
+			}
+			pipeline.Commands = append(pipeline.Commands, next)
+		case ANDAND, OROR:
+			pipeline.Ops = append(pipeline.Ops, p.cur().Kind)
+			p.advance()
+			next, err := p.parseCommand()
+			if err != nil {
+				return nil, err
+			}
+			pipeline.Commands = append(pipeline.Commands, next)
+		default:
+			return pipeline, nil
+		}
+	}
+}
+
+func (p *parser) parseCommand() (*Command, error) {
+	start := p.cur().Pos
+	cmd := &Command{StartPos: start}
+	for {
+		switch p.cur().Kind {
+		case WORD, STRING:
+			cmd.Words = append(cmd.Words, p.advance().Value)
+		case DLBRACKET:
+			p.advance()
+			for p.cur().Kind != DRBRACKET && p.cur().Kind != EOF {
+				if p.cur().Kind == QUESTION {
+					cmd.HasTernary = true
+				}
+				cmd.Words = append(cmd.Words, p.advance().Value)
+			}
+			if p.cur().Kind == DRBRACKET {
+				p.advance()
+			}
+		case DLPAREN:
+			p.advance()
+			for p.cur().Kind != DRPAREN && p.cur().Kind != EOF {
+				p.advance()
+			}
+			if p.cur().Kind == DRPAREN {
+				p.advance()
+			}
+		default:
+			return cmd, nil
+		}
+	}
+}
+
+// expect は現在のトークンが kind であれば読み進める。そうでなくても解析を
+// 止めずにベストエフォートで続行する(壊れた/未対応構文でも CCN を数え
+// られるようにするため)。
+func (p *parser) expect(kind TokenKind) {
+	if p.cur().Kind == kind {
+		p.advance()
+	}
+}
@@ -0,0 +1,114 @@
+package parser
+
+import "testing"
+
+// TestCountCCN は CalculateCCN の基礎となる CountCCN を、各種シェル構文に
+// ついて table-driven で検証する。期待値は「1(基底) + decision point の数」
+// というこのパッケージの数え方に基づく。
+func TestCountCCN(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want int
+	}{
+		{
+			name: "キーワードと同じ綴りの引数は decision point にならない",
+			src:  `echo done`,
+			want: 1,
+		},
+		{
+			name: "for の in に続くキーワード同名の引数がループ本体を閉じない",
+			src:  `for i in 1 2 3; do echo done || echo fail; done`,
+			want: 3, // for=1, ||=1, base=1
+		},
+		{
+			name: "case の各パターン節が decision point になる",
+			src: `case $x in
+  a|b) echo 1 ;;
+  c) echo 2 ;;
+esac`,
+			want: 3, // 2 clauses + base=1
+		},
+		{
+			name: "ネストした $( ) は decision point を持ち込まない",
+			src:  `x=$(echo $(echo hi))`,
+			want: 1,
+		},
+		{
+			name: "&& と || はそれぞれ decision point になる",
+			src:  `true && false || true`,
+			want: 3, // &&=1, ||=1, base=1
+		},
+		{
+			name: "[[ ]] 内の ? は三項演算子的利用として decision point になる",
+			src:  `if [[ $a -gt $b ? ]]; then echo x; fi`,
+			want: 3, // if=1, ?=1, base=1
+		},
+		{
+			name: "値が ? と一致する単語は三項演算子の利用と混同されない",
+			src:  `echo "?"`,
+			want: 1,
+		},
+		{
+			name: "[[ ]] 外の ? リテラルは三項演算子の利用と混同されない",
+			src:  `[ "$x" = "?" ] && grep "?" file`,
+			want: 2, // &&=1, base=1
+		},
+		{
+			name: "heredoc の本文はコードとして解釈されない",
+			src:  "cat <<EOF\nif done\nEOF",
+			want: 1,
+		},
+		{
+			name: "if と elif はそれぞれ decision point になり else はならない",
+			src: `if false; then
+  echo a
+elif false; then
+  echo b
+else
+  echo c
+fi`,
+			want: 3, // if=1, elif=1, base=1
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := ParseBlock(tt.src)
+			if err != nil {
+				t.Fatalf("ParseBlock: %v", err)
+			}
+			if got := CountCCN(block); got != tt.want {
+				t.Errorf("CountCCN(%q) = %d, want %d", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseSplitFuncDecl は `name()` と `{` が別行に分かれた関数定義を
+// 認識できることを確認する。
+func TestParseSplitFuncDecl(t *testing.T) {
+	src := "foo()\n{\n  echo hi\n}\n"
+	file, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	funcs := file.Functions()
+	if len(funcs) != 1 || funcs[0].Name != "foo" {
+		t.Fatalf("want a single function named foo, got %#v", funcs)
+	}
+}
+
+// TestParseExplicitFunctionKeyword は `function name { ... }` 形式を
+// 認識できることを確認する。
+func TestParseExplicitFunctionKeyword(t *testing.T) {
+	src := "function foo { echo hi; }"
+	file, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	funcs := file.Functions()
+	if len(funcs) != 1 || funcs[0].Name != "foo" {
+		t.Fatalf("want a single function named foo, got %#v", funcs)
+	}
+}
@@ -0,0 +1,58 @@
+// Package parser は shell スクリプトを字句解析・構文解析し、位置情報付きの
+// トークン列と小さな AST を提供する。regexp ベースの判定では heredoc や
+// ネストしたクオート、case パターンなどを正しく扱えないため、CCN の計算や
+// 関数抽出の土台として go/token, go/ast を参考にしたミニマルな実装を置く。
+package parser
+
+import "fmt"
+
+// TokenKind はトークンの種類を表す。
+//
+// if/while/for などのシェル予約語は単なる綴りでしかなく、`echo done` の
+// ように引数としても現れうる。どの綴りが実際にキーワードとして働くかは
+// 文法上の位置(コマンドの先頭か、for の後の in か、など)に依存するため、
+// ここでは専用の TokenKind を割り当てず、常に WORD として字句解析する。
+// キーワードかどうかの判定はパーサが文法位置に応じて Token.Value を見て
+// 行う。
+type TokenKind int
+
+// トークン種別の一覧。
+const (
+	WORD      TokenKind = iota // 単語(コマンド名・引数・予約語の綴りを含む)
+	STRING                     // '...' または "..." で囲まれた文字列
+	HEREDOC                    // heredoc 本文(1トークンにまとめる)
+	LBRACE                     // {
+	RBRACE                     // }
+	LPAREN                     // (
+	RPAREN                     // )
+	DLPAREN                    // ((
+	DRPAREN                    // ))
+	DLBRACKET                  // [[
+	DRBRACKET                  // ]]
+	SEMI                       // ;
+	DSEMI                      // ;;
+	NEWLINE                    // \n
+	PIPE                       // |
+	ANDAND                     // &&
+	OROR                       // ||
+	AMP                        // &
+	QUESTION                   // 引用符の外で単語に含まれない ?(三項演算子的な利用とみなすのは [[ ]] 内のみで、判定はパーサが行う)
+	EOF                        // 入力終端
+)
+
+// Position はソース上の位置(1始まりの行・列)を表す。
+type Position struct {
+	Line int
+	Col  int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Token は種別・値・開始位置を持つ字句解析の最小単位。
+type Token struct {
+	Kind  TokenKind
+	Value string
+	Pos   Position
+}
@@ -0,0 +1,33 @@
+package profile
+
+import (
+	"strings"
+
+	"github.com/ko1nksm/infra-tools/pkg/shccn"
+	"github.com/ko1nksm/infra-tools/pkg/shccn/parser"
+)
+
+// Collect は FileContents を解析し、含まれる関数それぞれの FuncProfile を
+// 返す。
+func Collect(fc *shccn.FileContents) ([]FuncProfile, error) {
+	file, err := parser.Parse(strings.Join(fc.Lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []FuncProfile
+	for _, fn := range file.Functions() {
+		body := shccn.FunctionBody(fc.Lines, fn.StartPos.Line, fn.EndPos.Line)
+		profiles = append(profiles, FuncProfile{
+			FileName:  fc.Name,
+			StartLine: fn.StartPos.Line + fc.LineOffset,
+			StartCol:  fn.StartPos.Col,
+			EndLine:   fn.EndPos.Line + fc.LineOffset,
+			EndCol:    fn.EndPos.Col,
+			Name:      fn.Name,
+			CCN:       parser.CountCCN(fn.Body),
+			Code:      len(shccn.GetCodes(body)),
+		})
+	}
+	return profiles, nil
+}
@@ -0,0 +1,164 @@
+// Package profile は `go tool cover` のカバレッジプロファイルを参考にした、
+// 機械可読なテキスト形式で CCN の計測結果をやり取りする。CI でのしきい値
+// 判定や外部ダッシュボードへの取り込みを、人間向けのサマリ表を再パースせず
+// 行えるようにするためのもの。
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Mode はプロファイルの1行目に書かれるモード名。今のところ ccn のみ。
+const Mode = "ccn"
+
+// FuncProfile はプロファイル中の1関数ぶんのエントリ。
+type FuncProfile struct {
+	FileName  string
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	Name      string
+	CCN       int
+	Code      int
+}
+
+// String は WriteProfile が書き出す1行分のテキストを返す。
+//
+//	<file>:<startLine>.<startCol>,<endLine>.<endCol> <name> <ccn> <codeLines>
+//
+// <name> フィールドは go tool cover のプロファイル形式には無いが、`-func`
+// がプロファイルだけから(ソースを再パースせずに)関数名を復元できるよう
+// 追加してある。
+func (p FuncProfile) String() string {
+	return fmt.Sprintf("%s:%d.%d,%d.%d %s %d %d",
+		p.FileName, p.StartLine, p.StartCol, p.EndLine, p.EndCol, p.Name, p.CCN, p.Code)
+}
+
+// WriteProfile は profiles を "mode: ccn" ヘッダ付きのテキスト形式で w に
+// 書き出す。
+func WriteProfile(w io.Writer, profiles []FuncProfile) error {
+	if _, err := fmt.Fprintf(w, "mode: %s\n", Mode); err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		if _, err := fmt.Fprintln(w, p.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseProfile は WriteProfile が書き出した形式のプロファイルを読み込む。
+func ParseProfile(r io.Reader) ([]FuncProfile, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("profile: empty input")
+	}
+	header := strings.TrimSpace(scanner.Text())
+	if !strings.HasPrefix(header, "mode:") {
+		return nil, fmt.Errorf("profile: bad header line %q", header)
+	}
+
+	var profiles []FuncProfile
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func parseLine(line string) (FuncProfile, error) {
+	// "<file>:<startLine>.<startCol>,<endLine>.<endCol> <name> <ccn> <codeLines>"
+	filePart, rest, ok := cut(line, " ")
+	if !ok {
+		return FuncProfile{}, fmt.Errorf("profile: malformed line %q", line)
+	}
+	file, rangePart, ok := cutLast(filePart, ":")
+	if !ok {
+		return FuncProfile{}, fmt.Errorf("profile: malformed location %q", filePart)
+	}
+	startPart, endPart, ok := cut(rangePart, ",")
+	if !ok {
+		return FuncProfile{}, fmt.Errorf("profile: malformed range %q", rangePart)
+	}
+	startLine, startCol, err := parsePoint(startPart)
+	if err != nil {
+		return FuncProfile{}, err
+	}
+	endLine, endCol, err := parsePoint(endPart)
+	if err != nil {
+		return FuncProfile{}, err
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) != 3 {
+		return FuncProfile{}, fmt.Errorf("profile: malformed line %q", line)
+	}
+	ccn, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return FuncProfile{}, fmt.Errorf("profile: bad ccn in %q: %w", line, err)
+	}
+	code, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return FuncProfile{}, fmt.Errorf("profile: bad code count in %q: %w", line, err)
+	}
+
+	return FuncProfile{
+		FileName:  file,
+		StartLine: startLine,
+		StartCol:  startCol,
+		EndLine:   endLine,
+		EndCol:    endCol,
+		Name:      fields[0],
+		CCN:       ccn,
+		Code:      code,
+	}, nil
+}
+
+func parsePoint(s string) (line, col int, err error) {
+	a, b, ok := cut(s, ".")
+	if !ok {
+		return 0, 0, fmt.Errorf("profile: malformed position %q", s)
+	}
+	line, err = strconv.Atoi(a)
+	if err != nil {
+		return 0, 0, fmt.Errorf("profile: bad line in %q: %w", s, err)
+	}
+	col, err = strconv.Atoi(b)
+	if err != nil {
+		return 0, 0, fmt.Errorf("profile: bad column in %q: %w", s, err)
+	}
+	return line, col, nil
+}
+
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
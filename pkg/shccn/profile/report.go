@@ -0,0 +1,41 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var funcTableSeparator = strings.Repeat("-", 80)
+
+// WriteFuncTable は profiles を CCN の降順に並べ替え、`go tool cover -func`
+// を模した表を w に書き出す。各行は関数名@スクリプト名、コード行数、CCN、
+// 全体の CCN 合計に対する割合を表示し、最後に total 行を付け加える。
+func WriteFuncTable(w io.Writer, profiles []FuncProfile) error {
+	sorted := make([]FuncProfile, len(profiles))
+	copy(sorted, profiles)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CCN > sorted[j].CCN })
+
+	totalCCN, totalCode := 0, 0
+	for _, p := range sorted {
+		totalCCN += p.CCN
+		totalCode += p.Code
+	}
+
+	fmt.Fprintf(w, "%s\n%-30s %10s %10s %10s\n%s\n",
+		funcTableSeparator, "Name", "Code", "CCN", "CCN%", funcTableSeparator)
+
+	for _, p := range sorted {
+		percent := 0.0
+		if totalCCN > 0 {
+			percent = float64(p.CCN) / float64(totalCCN) * 100
+		}
+		fmt.Fprintf(w, "%-30s %10d %10d %9.1f%%\n",
+			p.Name+"@"+p.FileName, p.Code, p.CCN, percent)
+	}
+
+	fmt.Fprintf(w, "%s\n%-30s %10d %10d %9.1f%%\n",
+		funcTableSeparator, "total", totalCode, totalCCN, 100.0)
+	return nil
+}
@@ -7,23 +7,25 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/ko1nksm/infra-tools/pkg/shccn/parser"
 )
 
 // ファイルの内容を保持する構造体
 type FileContents struct {
 	Name  string
 	Lines []string
+
+	// LineOffset は Lines の1行目が元ファイルの何行目に対応するかを示す
+	// オフセット(0始まり)。通常のファイルは常に0。mdextract が Markdown
+	// から取り出したコードブロックのように、Lines が元ファイルの一部分
+	// でしかない場合に、CCN/関数レポートが元ファイルの正しい行を指すよう
+	// 設定する。
+	LineOffset int
 }
 
-// TODO この正規表現達をローカル変数化するためのヘルパーメソッドを作ること
-var (
-	separator         = strings.Repeat("-", 80)                 // サマリレポートのセパレータ文字列
-	functionEndExp    = regexp.MustCompile(`}`)                 // 関数行(終了)の正規表現
-	functionNotEndExp = regexp.MustCompile(`.*'.*}.*|.*".*}.*`) // 関数行(終了)と見なさない正規表現
-	ccnExp            = regexp.MustCompile(`if|while|for|;;`)   // CCNでカウントアップするための正規表現
-	NotCcnExp         = regexp.MustCompile(`.*'.*if|.*".*if|.*'.*while|.*".*while|.*'.*for|.*".*for`)
-	conditionExp      = regexp.MustCompile(`&&|\|\|`) // CCNでカウントアップするための正規表現
-)
+// サマリレポートのセパレータ文字列
+var separator = strings.Repeat("-", 80)
 
 // ファイルの行数を返却する
 func (fc *FileContents) GetLines() int {
@@ -117,47 +119,95 @@ func GetCodes(code []string) (result []string) {
 }
 
 // ファイルの内容から関数行毎の辞書を作成する
-func GetFunctions(code []string) map[string][]string {
+//
+// 内部では parser パッケージでソース全体を AST に解析し、トップレベルの
+// 関数定義の行範囲を使って各行を振り分ける。関数の宣言行(`name() {`)と
+// 閉じ括弧の行は元の実装同様に結果へ含めない。ただし `name() { ... }` の
+// ように宣言行と閉じ括弧行が同一行になる1行関数では、その行自体が本体を
+// 兼ねるのでそのまま割り当てる。関数に属さない行は "BARE_CODE" キーに
+// まとめる。本体行が1行も無い関数(1行関数を含む)も、呼び出し側の集計
+// から漏れないよう必ずキーを作っておく。
+func GetFunctions(code []string) (map[string][]string, error) {
+	file, err := parser.Parse(strings.Join(code, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	type span struct {
+		name       string
+		start, end int
+	}
+	var spans []span
+	for _, fn := range file.Functions() {
+		spans = append(spans, span{name: fn.Name, start: fn.StartPos.Line, end: fn.EndPos.Line})
+	}
+
 	result := make(map[string][]string)
-	flag := false
-	var funcname string
-	for _, v := range code {
-		// 関数フラグが立っている場合、要素は無条件に追加してよい
-		// ただし}が含まれていれば、関数フラグをfalseにしておく
-		if flag {
-			if functionEndExp.MatchString(v) {
-				if functionNotEndExp.MatchString(v) {
-					result[funcname] = append(result[funcname], v)
-					continue
-				}
-				flag = false
-				funcname = ""
-				continue
-			}
-			result[funcname] = append(result[funcname], v)
-			continue
+	for _, s := range spans {
+		if _, ok := result[s.name]; !ok {
+			result[s.name] = nil
 		}
+	}
 
-		// 関数フラグが立っていない場合
-		// {が含まれていれば、要素を追加してよい
-		if isFunctionLine(v) {
-			funcname = GetFunctionName(v)
-			flag = true
-			continue
+	for i, line := range code {
+		lineNo := i + 1
+		matched := false
+		for _, s := range spans {
+			switch {
+			case s.start == s.end && lineNo == s.start:
+				result[s.name] = append(result[s.name], line)
+				matched = true
+			case lineNo == s.start || lineNo == s.end:
+				matched = true
+			case lineNo > s.start && lineNo < s.end:
+				result[s.name] = append(result[s.name], line)
+				matched = true
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			result["BARE_CODE"] = append(result["BARE_CODE"], line)
 		}
-
-		// {含まれていなければ、メイン処理扱い
-		funcname = "BARE_CODE"
-		result[funcname] = append(result[funcname], v)
 	}
 
-	return result
+	return result, nil
+}
+
+// FunctionBody は関数の開始行・終了行(宣言行と閉じ括弧行を含む、1始まり)
+// から、宣言行と閉じ括弧行を除いた本体部分の行を取り出す。html/profile の
+// 各パッケージが関数のコード行数を数える際に共通で使う(CCN 自体は行の
+// 再スライスではなく AST の FuncDecl.Body から直接計算する。`name() { ... }`
+// のように宣言行と閉じ括弧行が同一行になる1行関数では、切り出せる「間の
+// 行」が存在しないため、その行自体を返す)。
+func FunctionBody(lines []string, startLine, endLine int) []string {
+	if startLine < 1 || startLine > len(lines) {
+		return nil
+	}
+	if startLine == endLine {
+		return lines[startLine-1 : startLine]
+	}
+	if startLine+1 > endLine-1 {
+		return nil
+	}
+	lo, hi := startLine, endLine-1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(lines) {
+		hi = len(lines)
+	}
+	return lines[lo:hi]
 }
 
 // 関数名を取得する
 func GetFunctionName(line string) string {
-	r := strings.NewReplacer("function", "", "{", "", "(", "", ")", "", " ", "")
-	return r.Replace(line)
+	name, ok := parser.DetectFuncHeader(line)
+	if !ok {
+		return ""
+	}
+	return name
 }
 
 // サマリのヘッダ部を組み立てる
@@ -180,23 +230,17 @@ func BuildSummaryFooter() string {
 }
 
 // サイクロマティック複雑度を算出する
-func CalculateCCN(code []string) (result int) {
-	result = 1
-	for _, v := range code {
-		if ccnExp.MatchString(v) {
-			if NotCcnExp.MatchString(v) {
-				continue
-			}
-			result++
-		}
-
-		for _, element := range strings.Split(v, " ") {
-			if conditionExp.MatchString(element) {
-				result++
-			}
-		}
+//
+// 渡された行を parser パッケージで AST に解析し、if/elif/while/until/for、
+// case の各パターン、&&/||、[[ ]] 内の三項演算子的な ? 利用を decision
+// point として数える。パース不能な場合は decision point なし(=1)として
+// 扱う。
+func CalculateCCN(code []string) int {
+	block, err := parser.ParseBlock(strings.Join(code, "\n"))
+	if err != nil {
+		return 1
 	}
-	return result
+	return parser.CountCCN(block)
 }
 
 // 関数のヘッダ部を組み立てる
@@ -238,35 +282,6 @@ func isCommentLine(line string) bool {
 
 // 関数行かどうか判定する
 func isFunctionLine(line string) bool {
-	tmp := strings.Replace(line, `"`, `'`, -1)
-	if strings.Contains(tmp, `'`) {
-		line = removeQuote(line)
-	}
-	functionStartExp := regexp.MustCompile(`.*\(\s*\)\s*{`)
-	if functionStartExp.MatchString(line) {
-		return true
-	}
-	return false
-}
-
-// クオートに挟まれた文字列を除去する
-func removeQuote(line string) (result string) {
-	splited := strings.Split(line, "")
-	var tmp []string
-	flag := false
-	for _, v := range splited {
-		if flag {
-			if strings.Contains(v, `'`) {
-				flag = false
-				continue
-			}
-			continue
-		}
-		if strings.Contains(v, `'`) {
-			flag = true
-			continue
-		}
-		tmp = append(tmp, v)
-	}
-	return strings.Join(tmp, "")
+	_, ok := parser.DetectFuncHeader(line)
+	return ok
 }
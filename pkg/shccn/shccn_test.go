@@ -0,0 +1,77 @@
+package shccn
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestGetFunctionsSingleLine は、宣言行と閉じ括弧行が同一行になる1行関数
+// (`name() { ... }`)が BARE_CODE に取り込まれたり、結果から丸ごと消えたり
+// しないことを確認する。
+func TestGetFunctionsSingleLine(t *testing.T) {
+	code := []string{
+		`one() { echo hi || echo bye; }`,
+		`two() {`,
+		`  echo hi || echo bye`,
+		`}`,
+	}
+
+	got, err := GetFunctions(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{code[0]}; !reflect.DeepEqual(got["one"], want) {
+		t.Errorf("one = %v, want %v", got["one"], want)
+	}
+	if want := []string{code[2]}; !reflect.DeepEqual(got["two"], want) {
+		t.Errorf("two = %v, want %v", got["two"], want)
+	}
+	if _, ok := got["BARE_CODE"]; ok {
+		t.Errorf("BARE_CODE should be empty, got %v", got["BARE_CODE"])
+	}
+}
+
+// TestGetFunctionsEmptyBody は、本体行を持たない関数(宣言行の直後に閉じ
+// 括弧が来る場合)でも結果にキーが作られることを確認する。
+func TestGetFunctionsEmptyBody(t *testing.T) {
+	code := []string{
+		`empty() {`,
+		`}`,
+	}
+
+	got, err := GetFunctions(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, ok := got["empty"]
+	if !ok {
+		t.Fatalf("empty: key missing from result")
+	}
+	if len(body) != 0 {
+		t.Errorf("empty body = %v, want empty", body)
+	}
+}
+
+// TestFunctionBodySingleLine は、宣言行と閉じ括弧行が同一行の場合に
+// FunctionBody がその行自体を返すことを確認する。
+func TestFunctionBodySingleLine(t *testing.T) {
+	lines := []string{`one() { echo hi || echo bye; }`}
+
+	got := FunctionBody(lines, 1, 1)
+	want := []string{lines[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FunctionBody(lines, 1, 1) = %v, want %v", got, want)
+	}
+}
+
+// TestFunctionBodyEmpty は、本体行が存在しない2行関数に対して
+// FunctionBody が nil を返すことを確認する(従来どおりの挙動)。
+func TestFunctionBodyEmpty(t *testing.T) {
+	lines := []string{`empty() {`, `}`}
+
+	if got := FunctionBody(lines, 1, 2); got != nil {
+		t.Errorf("FunctionBody(lines, 1, 2) = %v, want nil", got)
+	}
+}